@@ -4,6 +4,7 @@ package migrate
 import (
 	"context"
 	"log"
+	"math"
 	"time"
 
 	"github.com/globalsign/mgo/bson"
@@ -15,8 +16,17 @@ type versionRecord struct {
 	Version     uint64
 	Description string `bson:",omitempty"`
 	Timestamp   time.Time
+	// Direction records whether this entry applied ("up") or rolled back
+	// ("down") Version. Records written before this field existed have it
+	// empty, and are treated as "up" for compatibility.
+	Direction string `bson:",omitempty"`
 }
 
+const (
+	directionUp   = "up"
+	directionDown = "down"
+)
+
 const defaultMigrationsCollection = "migrations"
 
 // AllAvailable used in "Up" or "Down" methods to run all available migrations.
@@ -25,13 +35,19 @@ const AllAvailable = -1
 // Migrate is type for performing migrations in provided database.
 // Database versioned using dedicated collection.
 // Each migration applying ("up" and "down") adds new document to collection.
-// This document consists migration version, migration description and timestamp.
-// Current database version determined as version in latest added document (biggest "_id") from collection mentioned above.
+// This document consists migration version, migration description, direction
+// ("up" or "down") and timestamp.
+// Current database version is determined by replaying that collection: a
+// version counts as applied once its latest recorded direction is "up" and
+// no later "down" record has rolled it back; the current version is the
+// greatest version still applied.
 type Migrate struct {
 	db                   *mongo.Database
 	migrations           []Migration
 	migrationsCollection string
 	logger               *log.Logger
+	useTransactions      bool
+	allowMissing         bool
 }
 
 func NewMigrate(db *mongo.Database, migrations ...Migration) *Migrate {
@@ -55,8 +71,27 @@ func (m *Migrate) SetLogger(l *log.Logger) {
 	m.logger = l
 }
 
-func (m *Migrate) isCollectionExist(name string) (bool, error) {
-	colls, err := m.db.ListCollectionNames(context.Background(), bson.D{})
+// SetUseTransactions makes every migration run inside a MongoDB transaction
+// together with the write of its version record, so a failure never leaves
+// the two out of sync. To opt in a single migration instead, set
+// Migration.Transactional. Requires the target MongoDB to be a replica set
+// or sharded cluster; plain standalone servers will return an error.
+func (m *Migrate) SetUseTransactions(use bool) {
+	m.useTransactions = use
+}
+
+// SetAllowMissing controls how UpContext/Up handle migrations with a version
+// lower than the database's current version that were never applied (for
+// example after merging two branches that both added migrations). When
+// false (the default), such a run fails with a descriptive error. When true,
+// the missing migrations are applied, in version order, before advancing
+// past the current version. See Missing to inspect them upfront.
+func (m *Migrate) SetAllowMissing(allow bool) {
+	m.allowMissing = allow
+}
+
+func (m *Migrate) isCollectionExist(ctx context.Context, name string) (bool, error) {
+	colls, err := m.db.ListCollectionNames(ctx, bson.D{})
 	if err != nil {
 		return false, err
 	}
@@ -68,8 +103,8 @@ func (m *Migrate) isCollectionExist(name string) (bool, error) {
 	return false, nil
 }
 
-func (m *Migrate) createCollectionIfNotExist(name string) error {
-	exist, err := m.isCollectionExist(name)
+func (m *Migrate) createCollectionIfNotExist(ctx context.Context, name string) error {
+	exist, err := m.isCollectionExist(ctx, name)
 	if err != nil {
 		return err
 	}
@@ -77,7 +112,7 @@ func (m *Migrate) createCollectionIfNotExist(name string) error {
 		return nil
 	}
 	// I had a problem here with bson.D: mongo returned error like "command not found: '0'"
-	result := m.db.RunCommand(context.Background(), struct {
+	result := m.db.RunCommand(ctx, struct {
 		Create string `bson:"create"`
 	}{
 		Create: name,
@@ -86,65 +121,138 @@ func (m *Migrate) createCollectionIfNotExist(name string) error {
 	return result.Err()
 }
 
-// Version returns current database version and comment.
-func (m *Migrate) Version() (uint64, string, error) {
-	if err := m.createCollectionIfNotExist(m.migrationsCollection); err != nil {
-		return 0, "", err
+// appliedVersions loads every record in the migrations collection, in
+// timestamp order, and replays it with replayVersionRecords.
+func (m *Migrate) appliedVersions(ctx context.Context) (map[uint64]string, map[uint64]versionRecord, error) {
+	if err := m.createCollectionIfNotExist(ctx, m.migrationsCollection); err != nil {
+		return nil, nil, err
 	}
 
-	var rec versionRecord
-	opts := options.FindOptions{
-		Sort: bson.M{"_id": -1},
+	cursor, err := m.db.Collection(m.migrationsCollection).Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []versionRecord
+	for cursor.Next(ctx) {
+		var rec versionRecord
+		if err := cursor.Decode(&rec); err != nil {
+			return nil, nil, err
+		}
+		records = append(records, rec)
 	}
-	// find record with greatest id (assuming it`s latest also)
-	cursor, err := m.db.Collection(m.migrationsCollection).Find(context.Background(), bson.M{}, &opts)
-	if err == mongo.ErrNoDocuments {
-		return 0, "", nil
+	if err := cursor.Err(); err != nil {
+		return nil, nil, err
 	}
+
+	applied, last := replayVersionRecords(records)
+	return applied, last, nil
+}
+
+// replayVersionRecords takes version records in timestamp order and returns
+// the set of versions that are currently applied (their latest record is an
+// "up" not followed by a "down"), mapped to their description, along with the
+// most recent record seen for every version regardless of direction.
+func replayVersionRecords(records []versionRecord) (map[uint64]string, map[uint64]versionRecord) {
+	applied := map[uint64]string{}
+	last := map[uint64]versionRecord{}
+	for _, rec := range records {
+		last[rec.Version] = rec
+		if rec.Direction == directionDown {
+			delete(applied, rec.Version)
+		} else {
+			applied[rec.Version] = rec.Description
+		}
+	}
+	return applied, last
+}
+
+// VersionContext returns current database version and comment.
+func (m *Migrate) VersionContext(ctx context.Context) (uint64, string, error) {
+	applied, _, err := m.appliedVersions(ctx)
 	if err != nil {
 		return 0, "", err
 	}
-	decodeErr := cursor.Decode(&rec)
-	if decodeErr != nil {
-		return 0, "", decodeErr
+
+	var version uint64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
 	}
-	return rec.Version, rec.Description, nil
+	return version, applied[version], nil
 }
 
-// Applied check if version was applied
-func (m *Migrate) Applied(version uint64) bool {
-	if err := m.createCollectionIfNotExist(m.migrationsCollection); err != nil {
+// Version returns current database version and comment.
+func (m *Migrate) Version() (uint64, string, error) {
+	return m.VersionContext(context.Background())
+}
+
+// AppliedContext check if version was applied
+func (m *Migrate) AppliedContext(ctx context.Context, version uint64) bool {
+	if err := m.createCollectionIfNotExist(ctx, m.migrationsCollection); err != nil {
 		return false
 	}
-	var rec versionRecord
-	cursor, err := m.db.Collection(m.migrationsCollection).Find(context.Background(), bson.M{"version": version})
-	if err == mongo.ErrNoDocuments {
+	// find record with greatest id (assuming it`s latest also) for this version only
+	cursor, err := m.db.Collection(m.migrationsCollection).Find(ctx, bson.M{"version": version}, options.Find().SetSort(bson.M{"_id": -1}))
+	if err != nil {
 		return false
 	}
-	if err != nil {
+	defer cursor.Close(ctx)
+	if !cursor.Next(ctx) {
 		return false
 	}
-	decodeErr := cursor.Decode(&rec)
-	if decodeErr != nil {
+	var rec versionRecord
+	if err := cursor.Decode(&rec); err != nil {
 		return false
 	}
-	return true
+	return rec.Direction != directionDown
 }
 
-// SetVersion forcibly changes database version to provided.
-func (m *Migrate) SetVersion(version uint64, description string) error {
-	_, err := m.db.Collection(m.migrationsCollection).InsertOne(context.Background(), versionRecord{
+// Applied check if version was applied
+func (m *Migrate) Applied(version uint64) bool {
+	return m.AppliedContext(context.Background(), version)
+}
+
+// insertVersionRecord writes a single migrations collection entry recording
+// a transition of version in the given direction.
+func (m *Migrate) insertVersionRecord(ctx context.Context, version uint64, description, direction string) error {
+	_, err := m.db.Collection(m.migrationsCollection).InsertOne(ctx, versionRecord{
 		Version:     version,
 		Timestamp:   time.Now().UTC(),
 		Description: description,
+		Direction:   direction,
 	})
 	return err
 }
 
-// Up performs "up" migrations to latest available version.
+// SetVersionContext records version as applied. The current database version
+// becomes version only if no greater version is already applied; lower
+// versions are not marked as applied.
+func (m *Migrate) SetVersionContext(ctx context.Context, version uint64, description string) error {
+	return m.insertVersionRecord(ctx, version, description, directionUp)
+}
+
+// SetVersion records version as applied. The current database version becomes
+// version only if no greater version is already applied.
+func (m *Migrate) SetVersion(version uint64, description string) error {
+	return m.SetVersionContext(context.Background(), version, description)
+}
+
+// UpContext performs "up" migrations to latest available version.
 // If n<=0 all "up" migrations with newer versions will be performed.
 // If n>0 only n migrations with newer version will be performed.
-func (m *Migrate) Up(n int) error {
+func (m *Migrate) UpContext(ctx context.Context, n int) error {
+	if err := m.ensureNoMissing(ctx, math.MaxUint64); err != nil {
+		return err
+	}
+
+	applied, _, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+
 	if n <= 0 || n > len(m.migrations) {
 		n = len(m.migrations)
 	}
@@ -152,28 +260,29 @@ func (m *Migrate) Up(n int) error {
 
 	for i, p := 0, 0; i < len(m.migrations) && p < n; i++ {
 		migration := m.migrations[i]
-		if m.Applied(migration.Version) || migration.Up == nil {
+		if _, ok := applied[migration.Version]; ok || migration.Up == nil {
 			continue
 		}
 		p++
-		if err := migration.Up(m.db); err != nil {
-			return err
-		}
-		if m.logger != nil {
-			m.logger.Printf("MIGRATED UP: %d %s\n", migration.Version, migration.Description)
-		}
-		if err := m.SetVersion(migration.Version, migration.Description); err != nil {
+		if err := m.applyUp(ctx, migration); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// Down performs "down" migration to oldest available version.
+// Up performs "up" migrations to latest available version.
+// If n<=0 all "up" migrations with newer versions will be performed.
+// If n>0 only n migrations with newer version will be performed.
+func (m *Migrate) Up(n int) error {
+	return m.UpContext(context.Background(), n)
+}
+
+// DownContext performs "down" migration to oldest available version.
 // If n<=0 all "down" migrations with older version will be performed.
 // If n>0 only n migrations with older version will be performed.
-func (m *Migrate) Down(n int) error {
-	currentVersion, _, err := m.Version()
+func (m *Migrate) DownContext(ctx context.Context, n int) error {
+	currentVersion, _, err := m.VersionContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -188,22 +297,88 @@ func (m *Migrate) Down(n int) error {
 			continue
 		}
 		p++
-		if err := migration.Down(m.db); err != nil {
+		if err := m.applyDown(ctx, migration); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		var prevMigration Migration
-		if i == 0 {
-			prevMigration = Migration{Version: 0}
-		} else {
-			prevMigration = m.migrations[i-1]
+// Down performs "down" migration to oldest available version.
+// If n<=0 all "down" migrations with older version will be performed.
+// If n>0 only n migrations with older version will be performed.
+func (m *Migrate) Down(n int) error {
+	return m.DownContext(context.Background(), n)
+}
+
+// UpToContext applies "up" migrations until the current version equals
+// target. It returns an error if no registered migration has that version.
+func (m *Migrate) UpToContext(ctx context.Context, target uint64) error {
+	if _, err := m.Current(target); err != nil {
+		return err
+	}
+	if err := m.ensureNoMissing(ctx, target); err != nil {
+		return err
+	}
+
+	applied, _, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+	migrationSort(m.migrations)
+
+	for _, migration := range m.migrations {
+		if migration.Version > target {
+			break
 		}
-		if m.logger != nil {
-			m.logger.Printf("MIGRATED DOWN: %d %s\n", migration.Version, migration.Description)
+		if _, ok := applied[migration.Version]; ok || migration.Up == nil {
+			continue
 		}
-		if err := m.SetVersion(prevMigration.Version, prevMigration.Description); err != nil {
+		if err := m.applyUp(ctx, migration); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// UpTo applies "up" migrations until the current version equals target.
+func (m *Migrate) UpTo(target uint64) error {
+	return m.UpToContext(context.Background(), target)
+}
+
+// DownToContext rolls back "down" migrations until the current version
+// equals target. It returns an error if target is non-zero and no registered
+// migration has that version.
+func (m *Migrate) DownToContext(ctx context.Context, target uint64) error {
+	if target != 0 {
+		if _, err := m.Current(target); err != nil {
+			return err
+		}
+	}
+
+	applied, _, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+	migrationSort(m.migrations)
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok || migration.Down == nil {
+			continue
+		}
+		if err := m.applyDown(ctx, migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DownTo rolls back "down" migrations until the current version equals
+// target.
+func (m *Migrate) DownTo(target uint64) error {
+	return m.DownToContext(context.Background(), target)
+}
@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReplayVersionRecordsTracksUpAndDown(t *testing.T) {
+	t0 := time.Unix(0, 0)
+	records := []versionRecord{
+		{Version: 1, Description: "one", Direction: directionUp, Timestamp: t0},
+		{Version: 2, Description: "two", Direction: directionUp, Timestamp: t0.Add(time.Second)},
+		{Version: 2, Description: "two", Direction: directionDown, Timestamp: t0.Add(2 * time.Second)},
+	}
+
+	applied, last := replayVersionRecords(records)
+
+	want := map[uint64]string{1: "one"}
+	if !reflect.DeepEqual(applied, want) {
+		t.Fatalf("applied = %+v, want %+v", applied, want)
+	}
+	if _, ok := last[2]; !ok {
+		t.Fatal("last should still remember version 2's most recent record")
+	}
+	if last[2].Direction != directionDown {
+		t.Fatalf("last[2].Direction = %q, want %q", last[2].Direction, directionDown)
+	}
+}
+
+func TestReplayVersionRecordsTreatsEmptyDirectionAsUp(t *testing.T) {
+	records := []versionRecord{
+		{Version: 1, Description: "legacy"},
+	}
+
+	applied, _ := replayVersionRecords(records)
+
+	if desc, ok := applied[1]; !ok || desc != "legacy" {
+		t.Fatalf("applied[1] = %q, %v, want \"legacy\", true", desc, ok)
+	}
+}
+
+func TestReplayVersionRecordsReappliedAfterDown(t *testing.T) {
+	records := []versionRecord{
+		{Version: 1, Direction: directionUp},
+		{Version: 1, Direction: directionDown},
+		{Version: 1, Description: "redone", Direction: directionUp},
+	}
+
+	applied, _ := replayVersionRecords(records)
+
+	if desc, ok := applied[1]; !ok || desc != "redone" {
+		t.Fatalf("applied[1] = %q, %v, want \"redone\", true", desc, ok)
+	}
+}
+
+func TestMigrationSort(t *testing.T) {
+	migrations := []Migration{{Version: 3}, {Version: 1}, {Version: 2}}
+	migrationSort(migrations)
+
+	for i, want := range []uint64{1, 2, 3} {
+		if migrations[i].Version != want {
+			t.Fatalf("migrations[%d].Version = %d, want %d", i, migrations[i].Version, want)
+		}
+	}
+}
+
+func TestMigrationsReturnsDefensiveCopy(t *testing.T) {
+	m := NewMigrate(nil, Migration{Version: 2}, Migration{Version: 1})
+
+	out := m.Migrations()
+	if len(out) != 2 || out[0].Version != 1 || out[1].Version != 2 {
+		t.Fatalf("Migrations() = %+v", out)
+	}
+
+	out[0].Version = 99
+	if m.migrations[0].Version == 99 {
+		t.Fatal("Migrations() did not return a defensive copy")
+	}
+}
+
+func TestCurrentNextPrevious(t *testing.T) {
+	m := NewMigrate(nil, Migration{Version: 1}, Migration{Version: 3}, Migration{Version: 5})
+
+	if mig, err := m.Current(3); err != nil || mig.Version != 3 {
+		t.Fatalf("Current(3) = %+v, %v", mig, err)
+	}
+	if _, err := m.Current(4); err == nil {
+		t.Fatal("Current(4) expected an error")
+	}
+
+	if mig, err := m.Next(2); err != nil || mig.Version != 3 {
+		t.Fatalf("Next(2) = %+v, %v", mig, err)
+	}
+	if _, err := m.Next(5); err == nil {
+		t.Fatal("Next(5) expected an error")
+	}
+
+	if mig, err := m.Previous(4); err != nil || mig.Version != 3 {
+		t.Fatalf("Previous(4) = %+v, %v", mig, err)
+	}
+	if _, err := m.Previous(1); err == nil {
+		t.Fatal("Previous(1) expected an error")
+	}
+}
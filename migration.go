@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrationFunc is the func signature for a single "up" or "down" migration step.
+type MigrationFunc func(ctx context.Context, db *mongo.Database) error
+
+// Migration represents single database migration.
+type Migration struct {
+	Version     uint64
+	Description string
+	Up          MigrationFunc
+	Down        MigrationFunc
+	// Transactional, when true, makes this migration run inside a MongoDB
+	// transaction together with the write of its version record, regardless
+	// of Migrate.SetUseTransactions. Requires a replica set or sharded cluster.
+	Transactional bool
+}
+
+// migrationSort sorts migrations in ascending order by version.
+func migrationSort(m []Migration) {
+	sort.Slice(m, func(i, j int) bool {
+		return m[i].Version < m[j].Version
+	})
+}
+
+// Migrations returns a defensive copy of the registered migrations, sorted by
+// version.
+func (m *Migrate) Migrations() []Migration {
+	migrationSort(m.migrations)
+	out := make([]Migration, len(m.migrations))
+	copy(out, m.migrations)
+	return out
+}
+
+// Current returns the registered migration with the given version.
+func (m *Migrate) Current(version uint64) (Migration, error) {
+	migrationSort(m.migrations)
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, nil
+		}
+	}
+	return Migration{}, fmt.Errorf("migrate: no migration with version %d", version)
+}
+
+// Next returns the registered migration with the smallest version greater
+// than version.
+func (m *Migrate) Next(version uint64) (Migration, error) {
+	migrationSort(m.migrations)
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			return migration, nil
+		}
+	}
+	return Migration{}, fmt.Errorf("migrate: no migration after version %d", version)
+}
+
+// Previous returns the registered migration with the greatest version less
+// than version.
+func (m *Migrate) Previous(version uint64) (Migration, error) {
+	migrationSort(m.migrations)
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if m.migrations[i].Version < version {
+			return m.migrations[i], nil
+		}
+	}
+	return Migration{}, fmt.Errorf("migrate: no migration before version %d", version)
+}
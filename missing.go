@@ -0,0 +1,89 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MissingContext returns registered migrations whose version is lower than
+// the database's current maximum applied version, yet were never applied.
+// This typically happens when two branches merge and both add migrations out
+// of version order. Migrations with no Up func are not included.
+func (m *Migrate) MissingContext(ctx context.Context) ([]Migration, error) {
+	applied, _, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	migrationSort(m.migrations)
+	return filterMissing(m.migrations, applied, math.MaxUint64), nil
+}
+
+// Missing returns registered migrations whose version is lower than the
+// database's current maximum applied version, yet were never applied.
+// Migrations with no Up func are not included.
+func (m *Migrate) Missing() ([]Migration, error) {
+	return m.MissingContext(context.Background())
+}
+
+// filterMissing returns the migrations in migrations (assumed sorted by
+// version) whose version is <= ceiling and lower than the greatest version
+// present in applied, yet is absent from it. Migrations with no Up func are
+// skipped: they can never stop being missing, so there is nothing to report
+// or apply.
+func filterMissing(migrations []Migration, applied map[uint64]string, ceiling uint64) []Migration {
+	var dbMaxVersion uint64
+	for version := range applied {
+		if version > dbMaxVersion {
+			dbMaxVersion = version
+		}
+	}
+
+	var missing []Migration
+	for _, migration := range migrations {
+		if migration.Version > ceiling || migration.Version >= dbMaxVersion || migration.Up == nil {
+			continue
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			missing = append(missing, migration)
+		}
+	}
+	return missing
+}
+
+// ensureNoMissing checks for missing migrations with a version <= ceiling
+// and either applies them (when SetAllowMissing(true)) or returns a
+// descriptive error listing them. Missing migrations above ceiling are left
+// alone: the caller hasn't asked to reach that far yet.
+func (m *Migrate) ensureNoMissing(ctx context.Context, ceiling uint64) error {
+	applied, _, err := m.appliedVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	migrationSort(m.migrations)
+	missing := filterMissing(m.migrations, applied, ceiling)
+	if len(missing) == 0 {
+		return nil
+	}
+	if !m.allowMissing {
+		return fmt.Errorf("migrate: missing migrations found: %s (enable with SetAllowMissing to apply them)", missingVersions(missing))
+	}
+	for _, migration := range missing {
+		if err := m.applyUp(ctx, migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func missingVersions(migrations []Migration) string {
+	versions := make([]string, len(migrations))
+	for i, migration := range migrations {
+		versions[i] = strconv.FormatUint(migration.Version, 10)
+	}
+	return strings.Join(versions, ", ")
+}
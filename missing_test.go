@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func noopMigrationFunc(ctx context.Context, db *mongo.Database) error { return nil }
+
+func TestFilterMissing(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Up: noopMigrationFunc},
+		{Version: 2, Up: noopMigrationFunc},
+		{Version: 3, Up: noopMigrationFunc},
+	}
+	applied := map[uint64]string{3: "three"}
+
+	missing := filterMissing(migrations, applied, math.MaxUint64)
+	if len(missing) != 2 || missing[0].Version != 1 || missing[1].Version != 2 {
+		t.Fatalf("filterMissing() = %+v", missing)
+	}
+}
+
+func TestFilterMissingRespectsCeiling(t *testing.T) {
+	// Migrations 1-5 registered, DB has 1 and 5 applied: 2, 3 and 4 are
+	// missing overall, but UpToContext(ctx, 2) should only ever see/apply
+	// migration 2 -- 3 and 4 have nothing to do with reaching version 2.
+	migrations := []Migration{
+		{Version: 1, Up: noopMigrationFunc},
+		{Version: 2, Up: noopMigrationFunc},
+		{Version: 3, Up: noopMigrationFunc},
+		{Version: 4, Up: noopMigrationFunc},
+		{Version: 5, Up: noopMigrationFunc},
+	}
+	applied := map[uint64]string{1: "one", 5: "five"}
+
+	if missing := filterMissing(migrations, applied, math.MaxUint64); len(missing) != 3 ||
+		missing[0].Version != 2 || missing[1].Version != 3 || missing[2].Version != 4 {
+		t.Fatalf("filterMissing() with no ceiling = %+v, want [2 3 4]", missing)
+	}
+
+	missing := filterMissing(migrations, applied, 2)
+	if len(missing) != 1 || missing[0].Version != 2 {
+		t.Fatalf("filterMissing() with ceiling 2 = %+v, want [2]", missing)
+	}
+}
+
+func TestFilterMissingSkipsMigrationsWithoutUp(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Up: nil},
+		{Version: 2, Up: noopMigrationFunc},
+	}
+	applied := map[uint64]string{2: "two"}
+
+	missing := filterMissing(migrations, applied, math.MaxUint64)
+	if len(missing) != 0 {
+		t.Fatalf("filterMissing() = %+v, want none: migration 1 has no Up func", missing)
+	}
+}
+
+func TestFilterMissingNoneBelowMax(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Up: noopMigrationFunc},
+		{Version: 2, Up: noopMigrationFunc},
+	}
+	applied := map[uint64]string{1: "one", 2: "two"}
+
+	missing := filterMissing(migrations, applied, math.MaxUint64)
+	if len(missing) != 0 {
+		t.Fatalf("filterMissing() = %+v, want none", missing)
+	}
+}
+
+func TestMissingVersions(t *testing.T) {
+	got := missingVersions([]Migration{{Version: 1}, {Version: 2}})
+	if got != "1, 2" {
+		t.Fatalf("missingVersions() = %q, want %q", got, "1, 2")
+	}
+}
+
+func TestMissingVersionsEmpty(t *testing.T) {
+	if got := missingVersions(nil); got != "" {
+		t.Fatalf("missingVersions(nil) = %q, want empty string", got)
+	}
+}
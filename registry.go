@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	registryMu sync.Mutex
+	registry   = map[uint64]Migration{}
+)
+
+// Register adds a migration to the global registry, to later be picked up by
+// NewMigrateFromRegistry. It is an error to register two migrations with the
+// same version. Since an init() has nowhere to send that error, callers that
+// register from a migration file's own init() (the usual way to split
+// migrations into one file per version, e.g. "001_add_users.go",
+// "002_index_emails.go", instead of maintaining one slice passed to
+// NewMigrate) should use MustRegister instead.
+func Register(version uint64, description string, up, down func(db *mongo.Database) error) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if existing, ok := registry[version]; ok {
+		return fmt.Errorf("migrate: migration with version %d already registered: %q and %q", version, existing.Description, description)
+	}
+
+	registry[version] = Migration{
+		Version:     version,
+		Description: description,
+		Up:          wrapRegisteredFunc(up),
+		Down:        wrapRegisteredFunc(down),
+	}
+	return nil
+}
+
+// MustRegister is like Register but panics if registration fails, matching
+// goose's registry behavior on a duplicate version. Call it from a migration
+// file's own init(), e.g. "001_add_users.go", "002_index_emails.go".
+func MustRegister(version uint64, description string, up, down func(db *mongo.Database) error) {
+	if err := Register(version, description, up, down); err != nil {
+		panic(err)
+	}
+}
+
+// NewMigrateFromRegistry creates a Migrate from every migration registered so
+// far via Register or MustRegister.
+func NewMigrateFromRegistry(db *mongo.Database) *Migrate {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	migrations := make([]Migration, 0, len(registry))
+	for _, migration := range registry {
+		migrations = append(migrations, migration)
+	}
+	return NewMigrate(db, migrations...)
+}
+
+// wrapRegisteredFunc adapts the plain *mongo.Database func signature used by
+// Register to MigrationFunc.
+func wrapRegisteredFunc(f func(db *mongo.Database) error) MigrationFunc {
+	if f == nil {
+		return nil
+	}
+	return func(ctx context.Context, db *mongo.Database) error {
+		return f(db)
+	}
+}
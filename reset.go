@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+)
+
+// ResetContext rolls every applied migration down to version 0 and then
+// migrates back up to the latest available version. It is particularly
+// useful for resetting a database in CI or local development.
+func (m *Migrate) ResetContext(ctx context.Context) error {
+	if m.logger != nil {
+		m.logger.Println("RESET: rolling back to version 0")
+	}
+	if err := m.DownToContext(ctx, 0); err != nil {
+		return err
+	}
+	return m.UpContext(ctx, AllAvailable)
+}
+
+// Reset rolls every applied migration down to version 0 and then migrates
+// back up to the latest available version.
+func (m *Migrate) Reset() error {
+	return m.ResetContext(context.Background())
+}
+
+// RedoContext rolls the migration at the current database version (the
+// greatest applied version) down and then immediately back up.
+func (m *Migrate) RedoContext(ctx context.Context) error {
+	version, _, err := m.VersionContext(ctx)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return fmt.Errorf("migrate: no applied migrations to redo")
+	}
+	migration, err := m.Current(version)
+	if err != nil {
+		return err
+	}
+	if migration.Down == nil || migration.Up == nil {
+		return fmt.Errorf("migrate: migration %d cannot be redone: missing Up or Down func", migration.Version)
+	}
+
+	if m.logger != nil {
+		m.logger.Printf("REDO: %d %s\n", migration.Version, migration.Description)
+	}
+	if err := m.applyDown(ctx, migration); err != nil {
+		return err
+	}
+	return m.applyUp(ctx, migration)
+}
+
+// Redo rolls the migration at the current database version down and then
+// immediately back up.
+func (m *Migrate) Redo() error {
+	return m.RedoContext(context.Background())
+}
@@ -0,0 +1,47 @@
+package migrate
+
+import (
+	"context"
+	"time"
+)
+
+// MigrationStatus describes the current state of a single registered
+// migration, as recorded in the migrations collection.
+type MigrationStatus struct {
+	Version     uint64
+	Description string
+	// Applied is true if the migration is currently applied, i.e. its
+	// latest recorded transition is "up".
+	Applied bool
+	// Timestamp is when the migration last transitioned (up or down).
+	// It is the zero Time if the migration was never applied or rolled back.
+	Timestamp time.Time
+}
+
+// StatusContext returns the status of every registered migration, ordered by
+// version.
+func (m *Migrate) StatusContext(ctx context.Context) ([]MigrationStatus, error) {
+	applied, last, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	migrationSort(m.migrations)
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		_, isApplied := applied[migration.Version]
+		statuses[i] = MigrationStatus{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Applied:     isApplied,
+			Timestamp:   last[migration.Version].Timestamp,
+		}
+	}
+	return statuses, nil
+}
+
+// Status returns the status of every registered migration, ordered by
+// version.
+func (m *Migrate) Status() ([]MigrationStatus, error) {
+	return m.StatusContext(context.Background())
+}
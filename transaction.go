@@ -0,0 +1,92 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// migrationError wraps an error returned by a migration's own Up/Down (or by
+// recording its version record) so withTransaction can tell it apart from
+// errors raised by the transaction machinery itself.
+type migrationError struct {
+	err error
+}
+
+func (e *migrationError) Error() string { return e.err.Error() }
+func (e *migrationError) Unwrap() error { return e.err }
+
+// applyUp runs a single "up" migration and records its version, joining a
+// MongoDB transaction when transactions are enabled for it.
+func (m *Migrate) applyUp(ctx context.Context, migration Migration) error {
+	if !m.useTransactions && !migration.Transactional {
+		if err := migration.Up(ctx, m.db); err != nil {
+			return err
+		}
+		if m.logger != nil {
+			m.logger.Printf("MIGRATED UP: %d %s\n", migration.Version, migration.Description)
+		}
+		return m.SetVersionContext(ctx, migration.Version, migration.Description)
+	}
+
+	return m.withTransaction(ctx, migration, func(sessCtx mongo.SessionContext) error {
+		if err := migration.Up(sessCtx, m.db); err != nil {
+			return err
+		}
+		if m.logger != nil {
+			m.logger.Printf("MIGRATED UP: %d %s\n", migration.Version, migration.Description)
+		}
+		return m.SetVersionContext(sessCtx, migration.Version, migration.Description)
+	})
+}
+
+// applyDown runs a single "down" migration and records it as rolled back,
+// joining a MongoDB transaction when transactions are enabled for it.
+func (m *Migrate) applyDown(ctx context.Context, migration Migration) error {
+	if !m.useTransactions && !migration.Transactional {
+		if err := migration.Down(ctx, m.db); err != nil {
+			return err
+		}
+		if m.logger != nil {
+			m.logger.Printf("MIGRATED DOWN: %d %s\n", migration.Version, migration.Description)
+		}
+		return m.insertVersionRecord(ctx, migration.Version, migration.Description, directionDown)
+	}
+
+	return m.withTransaction(ctx, migration, func(sessCtx mongo.SessionContext) error {
+		if err := migration.Down(sessCtx, m.db); err != nil {
+			return err
+		}
+		if m.logger != nil {
+			m.logger.Printf("MIGRATED DOWN: %d %s\n", migration.Version, migration.Description)
+		}
+		return m.insertVersionRecord(sessCtx, migration.Version, migration.Description, directionDown)
+	})
+}
+
+// withTransaction runs fn inside a MongoDB session transaction, so the
+// migration and its version record are committed (or rolled back) together.
+func (m *Migrate) withTransaction(ctx context.Context, migration Migration, fn func(sessCtx mongo.SessionContext) error) error {
+	sess, err := m.db.Client().StartSession()
+	if err != nil {
+		return fmt.Errorf("migrate: starting session for transactional migration %d: %w", migration.Version, err)
+	}
+	defer sess.EndSession(ctx)
+
+	_, err = sess.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if err := fn(sessCtx); err != nil {
+			return nil, &migrationError{err}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		var migErr *migrationError
+		if errors.As(err, &migErr) {
+			return migErr.err
+		}
+		return fmt.Errorf("migrate: transactional migration %d requires a replica set or sharded cluster: %w", migration.Version, err)
+	}
+	return nil
+}